@@ -0,0 +1,293 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Difficulty adjustment constants shared by every DifficultyRule.
+var (
+	big1          = big.NewInt(1)
+	big2          = big.NewInt(2)
+	big9          = big.NewInt(9)
+	big10         = big.NewInt(10)
+	bigMinus99    = big.NewInt(-99)
+	expDiffPeriod = big.NewInt(100000)
+)
+
+// DifficultyRule computes the difficulty of the block that follows parent,
+// to be mined at time. Both timestamps are plain uint64s; CalcDifficulty
+// extracts parentTime from parent.Time once, at the single point where this
+// package still has to cross the *big.Int boundary of types.Header.Time, so
+// no DifficultyRule implementation has to touch parent.Time itself. parent is
+// still passed through for its other fields (Number, Difficulty, UncleHash).
+type DifficultyRule func(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int
+
+// difficultyRuleEntry pairs a DifficultyRule with the predicate that decides
+// whether it applies to the block following parent.
+type difficultyRuleEntry struct {
+	name   string
+	active func(config *params.ChainConfig, next *big.Int) bool
+	calc   DifficultyRule
+}
+
+// difficultyRules is the registry consulted by CalcDifficulty, newest-fork
+// rule first. RegisterDifficultyRule maintains that ordering by prepending.
+var difficultyRules []difficultyRuleEntry
+
+// RegisterDifficultyRule adds a named difficulty rule to the registry consulted
+// by CalcDifficulty. active reports whether the rule governs the block
+// following parent (next = parent.Number+1).
+//
+// Rules registered later take priority over ones registered earlier, so a
+// chain package wiring up a new fork (or a brand new network) should register
+// its rule during init(), after the generic rules in this file have already
+// registered themselves, mirroring how params.ChainConfig fork-block fields
+// are themselves appended to over time without disturbing older ones.
+func RegisterDifficultyRule(name string, active func(config *params.ChainConfig, next *big.Int) bool, calc DifficultyRule) {
+	difficultyRules = append([]difficultyRuleEntry{{name: name, active: active, calc: calc}}, difficultyRules...)
+}
+
+func init() {
+	RegisterDifficultyRule("frontier", func(c *params.ChainConfig, next *big.Int) bool { return true }, calcDifficultyFrontier)
+	RegisterDifficultyRule("homestead", func(c *params.ChainConfig, next *big.Int) bool { return c.IsHomestead(next) }, calcDifficultyHomestead)
+	RegisterDifficultyRule("byzantium", func(c *params.ChainConfig, next *big.Int) bool { return c.IsEIP100F(next) }, calcDifficultyByzantium)
+	RegisterDifficultyRule("constantinople", func(c *params.ChainConfig, next *big.Int) bool { return c.IsEIP1234F(next) }, calcDifficultyEIP1234)
+	RegisterDifficultyRule("muirglacier", func(c *params.ChainConfig, next *big.Int) bool { return c.IsEIP2384F(next) }, calcDifficultyMuirGlacier)
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. It returns the
+// difficulty that a new block should have when created at time, given the
+// parent block's time and difficulty. The active DifficultyRule for the fork
+// that the next block belongs to is looked up from the registry populated by
+// RegisterDifficultyRule, so adding support for a new fork or a whole new
+// network no longer means touching this function.
+//
+// parent.Time.Uint64() below is the only place in this package that still
+// touches types.Header.Time as a *big.Int: every DifficultyRule takes
+// parentTime as a plain uint64 parameter instead of re-deriving it from
+// parent. Converting types.Header.Time itself to uint64 is out of this
+// package's control - core/types.Header is shared across the whole codebase
+// (RLP/JSON encoding, hashing, ...) - so that conversion is a separate,
+// broader migration; this is as much of it as consensus/ethash can do on its
+// own.
+func CalcDifficulty(config *params.ChainConfig, time uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	parentTime := parent.Time.Uint64()
+	for _, rule := range difficultyRules {
+		if rule.active(config, next) {
+			return rule.calc(config, time, parentTime, parent)
+		}
+	}
+	// Unreachable in practice: "frontier" is registered with an always-true
+	// predicate, but fall back to it explicitly rather than returning nil.
+	return calcDifficultyFrontier(config, time, parentTime, parent)
+}
+
+// calcDifficultyMuirGlacier implements the MuirGlacier (EIP-2384) difficulty
+// adjustment, identical in formula to Constantinople's but with the bomb
+// delayed to EIP2384FBlock rather than EIP1234FBlock.
+func calcDifficultyMuirGlacier(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	diff := sigmaDifficulty(time, parentTime, parent, big9)
+	fakeBlockNumber := bombDelayedNumber(config, next, config.EIP2384FBlock)
+	return addBombDelay(diff, fakeBlockNumber)
+}
+
+// calcDifficultyEIP1234 implements the Constantinople (EIP-1234) difficulty
+// adjustment, identical in formula to Byzantium's but with the bomb delayed
+// to EIP1234FBlock rather than EIP649FBlock.
+func calcDifficultyEIP1234(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	diff := sigmaDifficulty(time, parentTime, parent, big9)
+	fakeBlockNumber := bombDelayedNumber(config, next, config.EIP1234FBlock)
+	return addBombDelay(diff, fakeBlockNumber)
+}
+
+// calcDifficultyByzantium implements the Byzantium (EIP-100) difficulty
+// adjustment, which accounts for uncles in the adjustment factor and delays
+// the ice-age bomb to EIP649FBlock.
+func calcDifficultyByzantium(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	diff := sigmaDifficulty(time, parentTime, parent, big9)
+	fakeBlockNumber := bombDelayedNumber(config, next, config.EIP649FBlock)
+	return addBombDelay(diff, fakeBlockNumber)
+}
+
+// calcDifficultyHomestead implements the Homestead difficulty adjustment,
+// and additionally applies ETC's ECIP-1010 difficulty-bomb pause/continue
+// window when the chain config defines one.
+func calcDifficultyHomestead(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+
+	adjust := new(big.Int).Div(parent.Difficulty, params.DifficultyBoundDivisor)
+	x := new(big.Int)
+	if time >= parentTime {
+		delta := new(big.Int).SetUint64(time - parentTime)
+		delta.Div(delta, big10)
+		x.Sub(big1, delta)
+	} else {
+		x.Add(big1, new(big.Int).Div(new(big.Int).SetUint64(parentTime-time), big10))
+	}
+	if x.Cmp(bigMinus99) < 0 {
+		x = bigMinus99
+	}
+	adjust.Mul(adjust, x)
+
+	diff := new(big.Int).Add(parent.Difficulty, adjust)
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+
+	fakeBlockNumber := ecip1010FakeBlockNumber(config, next)
+	return addBombDelay(diff, fakeBlockNumber)
+}
+
+// calcDifficultyFrontier implements the original Frontier difficulty
+// adjustment, also subject to ECIP-1010's pause/continue window.
+func calcDifficultyFrontier(config *params.ChainConfig, time, parentTime uint64, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+
+	adjust := new(big.Int).Div(parent.Difficulty, params.DifficultyBoundDivisor)
+	diff := new(big.Int).Set(parent.Difficulty)
+	if time >= parentTime+13 {
+		diff.Sub(diff, adjust)
+	} else {
+		diff.Add(diff, adjust)
+	}
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+
+	fakeBlockNumber := ecip1010FakeBlockNumber(config, next)
+	return addBombDelay(diff, fakeBlockNumber)
+}
+
+// sigmaDifficulty returns parentDiff plus the post-EIP-100 sigma adjustment
+// parentDiff/2048 * max(y - (time-parentTime)/delayDivisor, -99), where y is
+// 2 if the parent has uncles and 1 otherwise, floored at MinimumDifficulty.
+func sigmaDifficulty(time, parentTime uint64, parent *types.Header, delayDivisor *big.Int) *big.Int {
+	y := big.NewInt(1)
+	if parent.UncleHash != types.EmptyUncleHash {
+		y = big2
+	}
+	x := new(big.Int)
+	if time >= parentTime {
+		delta := new(big.Int).SetUint64(time - parentTime)
+		delta.Div(delta, delayDivisor)
+		x.Sub(y, delta)
+	} else {
+		x.Add(y, new(big.Int).Div(new(big.Int).SetUint64(parentTime-time), delayDivisor))
+	}
+	if x.Cmp(bigMinus99) < 0 {
+		x = bigMinus99
+	}
+
+	adjust := new(big.Int).Div(parent.Difficulty, params.DifficultyBoundDivisor)
+	adjust.Mul(adjust, x)
+
+	diff := new(big.Int).Add(parent.Difficulty, adjust)
+	if diff.Cmp(params.MinimumDifficulty) < 0 {
+		diff.Set(params.MinimumDifficulty)
+	}
+	return diff
+}
+
+// ecip1010FakeBlockNumber applies ETC's ECIP-1010 difficulty-bomb pause: from
+// ECIP1010PauseBlock through ECIP1010PauseBlock+ECIP1010Length, the bomb's
+// block-number input is frozen at the pause block; beyond that window it
+// resumes counting ECIP1010Length blocks behind the real chain height.
+func ecip1010FakeBlockNumber(config *params.ChainConfig, next *big.Int) *big.Int {
+	if config.ECIP1010PauseBlock == nil || next.Cmp(config.ECIP1010PauseBlock) < 0 {
+		return next
+	}
+	if config.ECIP1010Length == nil {
+		return next
+	}
+	continueBlock := new(big.Int).Add(config.ECIP1010PauseBlock, config.ECIP1010Length)
+	if next.Cmp(continueBlock) < 0 {
+		return config.ECIP1010PauseBlock
+	}
+	return new(big.Int).Sub(next, config.ECIP1010Length)
+}
+
+// bombDelayedNumber returns the fake block number fed to the difficulty bomb
+// once EIP-649/EIP-1234's delay has kicked in at delayBlock, falling back to
+// ECIP-1010's pause/continue handling when no delay block is configured for
+// this fork, or when ECIP-1010's own window is still the one governing the
+// bomb (see ecip1010Active). Per EIP-649/EIP-1234, the delay is an offset
+// subtracted from the real block number, not a freeze at delayBlock: the bomb
+// must keep climbing (only pushed back), which is exactly why EIP-1234/2384/
+// 3554 and ECIP-1041 each had to delay it again rather than it staying fixed
+// forever.
+func bombDelayedNumber(config *params.ChainConfig, next, delayBlock *big.Int) *big.Int {
+	if delayBlock == nil || ecip1010Active(config, next) {
+		return ecip1010FakeBlockNumber(config, next)
+	}
+	// ECIP-1041 disposal pushes the offset out further still, but only once
+	// the chain has actually reached DisposalBlock; before that it must not
+	// override the EIP-649/EIP-1234 delay that is already in effect.
+	if config.DisposalBlock != nil && next.Cmp(config.DisposalBlock) >= 0 {
+		delayBlock = config.DisposalBlock
+	}
+	if next.Cmp(delayBlock) < 0 {
+		return next
+	}
+	fakeNumber := new(big.Int).Sub(next, delayBlock)
+	if fakeNumber.Sign() < 0 {
+		return new(big.Int)
+	}
+	return fakeNumber
+}
+
+// ecip1010Active reports whether next still falls under ECIP-1010's
+// pause/continue window rather than under an EIP-649/EIP-1234 delay block
+// that happens to be configured on the same chain (as ETC networks that adopt
+// both Atlantis/Agharta-style forks and the older ECIP-1010 schedule do).
+// ECIP-1010 governs from ECIP1010PauseBlock onward - both while frozen and
+// while resuming its own offset countdown - until ECIP-1041's DisposalBlock is
+// reached, at which point disposal permanently takes over and bombDelayedNumber
+// falls through to the EIP-649/EIP-1234 delayBlock branch instead.
+func ecip1010Active(config *params.ChainConfig, next *big.Int) bool {
+	if config.ECIP1010PauseBlock == nil || config.ECIP1010Length == nil {
+		return false
+	}
+	if next.Cmp(config.ECIP1010PauseBlock) < 0 {
+		return false
+	}
+	if config.DisposalBlock != nil && next.Cmp(config.DisposalBlock) >= 0 {
+		return false
+	}
+	return true
+}
+
+// addBombDelay adds the exponential ice-age component, 2^((fakeBlockNumber/100000)-2),
+// to diff, using fakeBlockNumber in place of the real block number so that
+// ECIP-1010/ECIP-1041/EIP-649/EIP-1234 delays are reflected.
+func addBombDelay(diff, fakeBlockNumber *big.Int) *big.Int {
+	periodCount := new(big.Int).Div(fakeBlockNumber, expDiffPeriod)
+	if periodCount.Cmp(big1) <= 0 {
+		return diff
+	}
+	bomb := new(big.Int).Sub(periodCount, big2)
+	bomb.Exp(big2, bomb, nil)
+	return diff.Add(diff, bomb)
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// networkConfigs maps the "network" strings used by the upstream
+// tests/difficulty_test.go vector schema to a params.ChainConfig with exactly
+// the fork-activation blocks needed to reproduce that network's difficulty
+// rules, including the ETC-specific ECIP-1010/ECIP-1041 networks that have no
+// equivalent in mainnet-only clients.
+var networkConfigs = map[string]*params.ChainConfig{
+	"Frontier": {
+		HomesteadBlock: nil,
+	},
+	"Homestead": {
+		HomesteadBlock: big.NewInt(0),
+	},
+	"Byzantium": {
+		HomesteadBlock: big.NewInt(0),
+		EIP100FBlock:   big.NewInt(0),
+		EIP649FBlock:   big.NewInt(0),
+	},
+	"Constantinople": {
+		HomesteadBlock: big.NewInt(0),
+		EIP100FBlock:   big.NewInt(0),
+		EIP649FBlock:   big.NewInt(0),
+		EIP1234FBlock:  big.NewInt(0),
+	},
+	// ETC_Atlantis activates the Byzantium difficulty formula (EIP-100) but
+	// keeps the bomb on ECIP-1010's pause/continue schedule rather than
+	// delaying it via EIP-649.
+	"ETC_Atlantis": {
+		HomesteadBlock:     big.NewInt(0),
+		EIP100FBlock:       big.NewInt(0),
+		ECIP1010PauseBlock: big.NewInt(3000000),
+		ECIP1010Length:     big.NewInt(2000000),
+	},
+	// ETC_Agharta layers the Constantinople formula (EIP-1234) on top of
+	// ETC_Atlantis, with the ECIP-1041 disposal block ending ECIP-1010's
+	// window: from DisposalBlock on, the bomb counts from DisposalBlock.
+	"ETC_Agharta": {
+		HomesteadBlock:     big.NewInt(0),
+		EIP100FBlock:       big.NewInt(0),
+		EIP1234FBlock:      big.NewInt(0),
+		ECIP1010PauseBlock: big.NewInt(3000000),
+		ECIP1010Length:     big.NewInt(2000000),
+		DisposalBlock:      big.NewInt(5900000),
+	},
+}
+
+// ChainConfigByNetwork returns the params.ChainConfig that reproduces the
+// difficulty rules named by network (as used by the "network" field of the
+// retesteth-schema difficulty test vectors). The bool result reports whether
+// network was recognized.
+func ChainConfigByNetwork(network string) (*params.ChainConfig, bool) {
+	config, ok := networkConfigs[network]
+	return config, ok
+}